@@ -0,0 +1,181 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package acp118
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/proto/pb/sdk"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+)
+
+var ErrInsufficientWeight = errors.New("failed to aggregate signatures: insufficient weight")
+
+// Sender delivers a serialized ACP-118 SignatureRequest to [nodeID], which is
+// expected to be a validator of [chainID]'s subnet, and returns the raw
+// SignatureResponse payload it replies with. Implementations are responsible
+// for any p2p-level framing (protocol prefixing, AppRequest/AppResponse
+// correlation, timeouts).
+//
+// Production callers typically back this with a *p2p.Client; tests may back
+// it with a directly connected peer.Peer.
+type Sender interface {
+	SendAppRequest(ctx context.Context, nodeID ids.NodeID, chainID ids.ID, requestBytes []byte) ([]byte, error)
+}
+
+// Aggregator collects and aggregates BLS signatures over a warp message from
+// the validator set of a subnet, as used by ACP-118 signature requests.
+type Aggregator struct {
+	subnetID   ids.ID
+	validators validators.State
+	sender     Sender
+	metrics    *metrics
+}
+
+// New returns an Aggregator that queries the validator set of [subnetID], as
+// reported by [validatorState], and collects signatures through [sender].
+// Per-request outcomes are reported under [registerer].
+func New(
+	subnetID ids.ID,
+	validatorState validators.State,
+	sender Sender,
+	registerer prometheus.Registerer,
+) (*Aggregator, error) {
+	m, err := newMetrics(registerer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register aggregator metrics: %w", err)
+	}
+
+	return &Aggregator{
+		subnetID:   subnetID,
+		validators: validatorState,
+		sender:     sender,
+		metrics:    m,
+	}, nil
+}
+
+type signatureResult struct {
+	index     int
+	signature *bls.Signature
+}
+
+// AggregateSignatures queries the validator set of [a.subnetID] at [height]
+// in parallel for a signature over [msg] (with optional [justification]),
+// verifies each response against the responding validator's BLS public key,
+// and aggregates them into a *warp.Message as soon as the weight of the
+// responding validators crosses quorumNum/quorumDen of the total validator
+// weight. It returns ErrInsufficientWeight if every queried validator has
+// answered or failed, or [ctx] is done, before the threshold is reached.
+func (a *Aggregator) AggregateSignatures(
+	ctx context.Context,
+	msg *warp.UnsignedMessage,
+	justification []byte,
+	height uint64,
+	quorumNum uint64,
+	quorumDen uint64,
+) (*warp.Message, error) {
+	vdrs, totalWeight, err := warp.GetCanonicalValidatorSetFromSubnetID(ctx, a.validators, height, a.subnetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get validator set of %s: %w", a.subnetID, err)
+	}
+
+	requestBytes, err := proto.Marshal(&sdk.SignatureRequest{
+		Message:       msg.Bytes(),
+		Justification: justification,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signature request: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resultsChan := make(chan signatureResult, len(vdrs))
+	var wg sync.WaitGroup
+	for i, vdr := range vdrs {
+		wg.Add(1)
+		go func(i int, vdr *warp.Validator) {
+			defer wg.Done()
+
+			nodeID := vdr.NodeIDs[0]
+
+			responseBytes, err := a.sender.SendAppRequest(ctx, nodeID, msg.SourceChainID, requestBytes)
+			if err != nil {
+				a.metrics.requestErrors.WithLabelValues(nodeID.String()).Inc()
+				return
+			}
+
+			var response sdk.SignatureResponse
+			if err := proto.Unmarshal(responseBytes, &response); err != nil {
+				a.metrics.requestErrors.WithLabelValues(nodeID.String()).Inc()
+				return
+			}
+
+			sig, err := bls.SignatureFromBytes(response.Signature)
+			if err != nil {
+				a.metrics.invalidSignatures.WithLabelValues(nodeID.String()).Inc()
+				return
+			}
+
+			if !bls.Verify(vdr.PublicKey, sig, msg.Bytes()) {
+				a.metrics.invalidSignatures.WithLabelValues(nodeID.String()).Inc()
+				return
+			}
+
+			select {
+			case resultsChan <- signatureResult{index: i, signature: sig}:
+			case <-ctx.Done():
+			}
+		}(i, vdr)
+	}
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	var (
+		signatures       []*bls.Signature
+		signerBits       = set.NewBits()
+		aggregatedWeight uint64
+	)
+	for res := range resultsChan {
+		signatures = append(signatures, res.signature)
+		signerBits.Add(res.index)
+		aggregatedWeight += vdrs[res.index].Weight
+
+		if aggregatedWeight*quorumDen >= totalWeight*quorumNum {
+			break
+		}
+	}
+	cancel() // stop any remaining in-flight requests once we stop consuming resultsChan
+
+	if aggregatedWeight*quorumDen < totalWeight*quorumNum {
+		return nil, fmt.Errorf("%w: aggregated %d/%d, needed %d/%d", ErrInsufficientWeight, aggregatedWeight, totalWeight, quorumNum, quorumDen)
+	}
+
+	aggregatedSignature, err := bls.AggregateSignatures(signatures)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate signatures: %w", err)
+	}
+
+	return warp.NewMessage(
+		msg,
+		&warp.BitSetSignature{
+			Signers: signerBits.Bytes(),
+			Signature: ([bls.SignatureLen]byte)(
+				bls.SignatureToBytes(aggregatedSignature),
+			),
+		},
+	)
+}
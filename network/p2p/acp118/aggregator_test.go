@@ -0,0 +1,225 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package acp118_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p/acp118"
+	"github.com/ava-labs/avalanchego/proto/pb/sdk"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+)
+
+const (
+	testNetworkID         = 12345
+	testQuorumNumerator   = 67
+	testQuorumDenominator = 100
+)
+
+var errUnreachable = errors.New("validator unreachable")
+
+// fakeValidatorState serves a fixed validator set regardless of height.
+type fakeValidatorState struct {
+	subnetID ids.ID
+	vdrs     map[ids.NodeID]*validators.GetValidatorOutput
+}
+
+func (*fakeValidatorState) GetMinimumHeight(context.Context) (uint64, error) { return 0, nil }
+func (*fakeValidatorState) GetCurrentHeight(context.Context) (uint64, error) { return 0, nil }
+
+func (f *fakeValidatorState) GetSubnetID(context.Context, ids.ID) (ids.ID, error) {
+	return f.subnetID, nil
+}
+
+func (f *fakeValidatorState) GetValidatorSet(
+	context.Context,
+	uint64,
+	ids.ID,
+) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+	return f.vdrs, nil
+}
+
+// fakeSender answers every SendAppRequest by delegating to respond, which is
+// given the unsigned message bytes the Aggregator asked [nodeID] to sign.
+type fakeSender struct {
+	respond func(nodeID ids.NodeID, unsignedMessage []byte) ([]byte, error)
+}
+
+func (s *fakeSender) SendAppRequest(_ context.Context, nodeID ids.NodeID, _ ids.ID, requestBytes []byte) ([]byte, error) {
+	var req sdk.SignatureRequest
+	if err := proto.Unmarshal(requestBytes, &req); err != nil {
+		return nil, err
+	}
+	return s.respond(nodeID, req.Message)
+}
+
+func signedResponse(t *testing.T, sk *bls.SecretKey, unsignedMessage []byte) []byte {
+	t.Helper()
+
+	sig := bls.Sign(sk, unsignedMessage)
+	responseBytes, err := proto.Marshal(&sdk.SignatureResponse{
+		Signature: bls.SignatureToBytes(sig),
+	})
+	require.NoError(t, err)
+	return responseBytes
+}
+
+func TestAggregateSignatures_SingleSignerMeetsQuorum(t *testing.T) {
+	require := require.New(t)
+
+	sk1, err := bls.NewSecretKey()
+	require.NoError(err)
+	sk2, err := bls.NewSecretKey()
+	require.NoError(err)
+	nodeID1, nodeID2 := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+	subnetID := ids.GenerateTestID()
+
+	sender := &fakeSender{
+		respond: func(nodeID ids.NodeID, unsignedMessage []byte) ([]byte, error) {
+			switch nodeID {
+			case nodeID1:
+				return signedResponse(t, sk1, unsignedMessage)
+			default:
+				// nodeID2 never responds; its 30% weight isn't needed to
+				// cross the 67/100 quorum on its own.
+				return nil, errUnreachable
+			}
+		},
+	}
+	state := &fakeValidatorState{
+		subnetID: subnetID,
+		vdrs: map[ids.NodeID]*validators.GetValidatorOutput{
+			nodeID1: {NodeID: nodeID1, PublicKey: bls.PublicFromSecretKey(sk1), Weight: 70},
+			nodeID2: {NodeID: nodeID2, PublicKey: bls.PublicFromSecretKey(sk2), Weight: 30},
+		},
+	}
+
+	aggregator, err := acp118.New(subnetID, state, sender, prometheus.NewRegistry())
+	require.NoError(err)
+
+	msg, err := warp.NewUnsignedMessage(testNetworkID, ids.GenerateTestID(), []byte("payload"))
+	require.NoError(err)
+
+	signed, err := aggregator.AggregateSignatures(context.Background(), msg, nil, 0, testQuorumNumerator, testQuorumDenominator)
+	require.NoError(err)
+	require.NotNil(signed)
+}
+
+func TestAggregateSignatures_RequiresBothSigners(t *testing.T) {
+	require := require.New(t)
+
+	sk1, err := bls.NewSecretKey()
+	require.NoError(err)
+	sk2, err := bls.NewSecretKey()
+	require.NoError(err)
+	nodeID1, nodeID2 := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+	subnetID := ids.GenerateTestID()
+
+	sender := &fakeSender{
+		respond: func(nodeID ids.NodeID, unsignedMessage []byte) ([]byte, error) {
+			if nodeID == nodeID1 {
+				return signedResponse(t, sk1, unsignedMessage)
+			}
+			return signedResponse(t, sk2, unsignedMessage)
+		},
+	}
+	state := &fakeValidatorState{
+		subnetID: subnetID,
+		vdrs: map[ids.NodeID]*validators.GetValidatorOutput{
+			nodeID1: {NodeID: nodeID1, PublicKey: bls.PublicFromSecretKey(sk1), Weight: 50},
+			nodeID2: {NodeID: nodeID2, PublicKey: bls.PublicFromSecretKey(sk2), Weight: 50},
+		},
+	}
+
+	aggregator, err := acp118.New(subnetID, state, sender, prometheus.NewRegistry())
+	require.NoError(err)
+
+	msg, err := warp.NewUnsignedMessage(testNetworkID, ids.GenerateTestID(), []byte("payload"))
+	require.NoError(err)
+
+	signed, err := aggregator.AggregateSignatures(context.Background(), msg, nil, 0, testQuorumNumerator, testQuorumDenominator)
+	require.NoError(err)
+	require.NotNil(signed)
+}
+
+func TestAggregateSignatures_InvalidSignatureIsNotCountedTowardQuorum(t *testing.T) {
+	require := require.New(t)
+
+	sk1, err := bls.NewSecretKey()
+	require.NoError(err)
+	sk2, err := bls.NewSecretKey()
+	require.NoError(err)
+	wrongSK, err := bls.NewSecretKey()
+	require.NoError(err)
+	nodeID1, nodeID2 := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+	subnetID := ids.GenerateTestID()
+
+	sender := &fakeSender{
+		respond: func(nodeID ids.NodeID, unsignedMessage []byte) ([]byte, error) {
+			if nodeID == nodeID1 {
+				return signedResponse(t, sk1, unsignedMessage)
+			}
+			// nodeID2 signs with the wrong key, so its signature won't
+			// verify against its registered public key.
+			return signedResponse(t, wrongSK, unsignedMessage)
+		},
+	}
+	state := &fakeValidatorState{
+		subnetID: subnetID,
+		vdrs: map[ids.NodeID]*validators.GetValidatorOutput{
+			nodeID1: {NodeID: nodeID1, PublicKey: bls.PublicFromSecretKey(sk1), Weight: 60},
+			nodeID2: {NodeID: nodeID2, PublicKey: bls.PublicFromSecretKey(sk2), Weight: 40},
+		},
+	}
+
+	aggregator, err := acp118.New(subnetID, state, sender, prometheus.NewRegistry())
+	require.NoError(err)
+
+	msg, err := warp.NewUnsignedMessage(testNetworkID, ids.GenerateTestID(), []byte("payload"))
+	require.NoError(err)
+
+	// nodeID1's weight (60) alone can't cross 67/100 quorum; if nodeID2's
+	// bogus signature were (incorrectly) counted, the combined weight (100)
+	// would cross it instead.
+	_, err = aggregator.AggregateSignatures(context.Background(), msg, nil, 0, testQuorumNumerator, testQuorumDenominator)
+	require.ErrorIs(err, acp118.ErrInsufficientWeight)
+}
+
+func TestAggregateSignatures_EmptyValidatorSet(t *testing.T) {
+	require := require.New(t)
+
+	subnetID := ids.GenerateTestID()
+	state := &fakeValidatorState{
+		subnetID: subnetID,
+		vdrs:     map[ids.NodeID]*validators.GetValidatorOutput{},
+	}
+	sender := &fakeSender{
+		respond: func(ids.NodeID, []byte) ([]byte, error) {
+			t.Fatal("no validator should be queried")
+			return nil, nil
+		},
+	}
+
+	aggregator, err := acp118.New(subnetID, state, sender, prometheus.NewRegistry())
+	require.NoError(err)
+
+	msg, err := warp.NewUnsignedMessage(testNetworkID, ids.GenerateTestID(), []byte("payload"))
+	require.NoError(err)
+
+	// With no validators, the aggregated (0) and total (0) weight trivially
+	// satisfy quorumNum/quorumDen, so this does not surface as
+	// ErrInsufficientWeight -- a subtlety of the quorum math worth pinning
+	// down explicitly rather than changing.
+	_, err = aggregator.AggregateSignatures(context.Background(), msg, nil, 0, testQuorumNumerator, testQuorumDenominator)
+	require.NotErrorIs(err, acp118.ErrInsufficientWeight)
+}
@@ -0,0 +1,33 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package acp118
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	requestErrors     *prometheus.CounterVec
+	invalidSignatures *prometheus.CounterVec
+}
+
+func newMetrics(registerer prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		requestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "signature_aggregator_request_errors",
+			Help: "Number of ACP-118 signature requests that errored or timed out, labeled by the queried validator's node ID",
+		}, []string{"node_id"}),
+		invalidSignatures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "signature_aggregator_invalid_signatures",
+			Help: "Number of ACP-118 signature responses that failed verification, labeled by the responding validator's node ID",
+		}, []string{"node_id"}),
+	}
+	for _, c := range []prometheus.Collector{
+		m.requestErrors,
+		m.invalidSignatures,
+	} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
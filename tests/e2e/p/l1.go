@@ -6,21 +6,25 @@ package p
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"math"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
-	"google.golang.org/protobuf/proto"
 
 	"github.com/ava-labs/avalanchego/api/info"
 	"github.com/ava-labs/avalanchego/config"
 	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p/acp118"
 	"github.com/ava-labs/avalanchego/network/peer"
-	"github.com/ava-labs/avalanchego/proto/pb/sdk"
 	"github.com/ava-labs/avalanchego/snow/networking/router"
+	"github.com/ava-labs/avalanchego/tests"
 	"github.com/ava-labs/avalanchego/tests/fixture/e2e"
 	"github.com/ava-labs/avalanchego/tests/fixture/tmpnet"
 	"github.com/ava-labs/avalanchego/utils"
@@ -29,7 +33,6 @@ import (
 	"github.com/ava-labs/avalanchego/utils/crypto/bls"
 	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
 	"github.com/ava-labs/avalanchego/utils/logging"
-	"github.com/ava-labs/avalanchego/utils/set"
 	"github.com/ava-labs/avalanchego/utils/units"
 	"github.com/ava-labs/avalanchego/vms/example/xsvm/genesis"
 	"github.com/ava-labs/avalanchego/vms/platformvm"
@@ -52,11 +55,31 @@ const (
 	registerWeight  = genesisWeight / 10
 	updatedWeight   = 2 * registerWeight
 	registerBalance = 0
+	topUpAmount     = units.Avax
 
 	// Validator registration attempts expire 5 minutes after they are created
 	expiryDelay = 5 * time.Minute
 	// P2P message requests timeout after 10 seconds
 	p2pTimeout = 10 * time.Second
+	// Number of times to (re)send an AppRequest before giving up on its
+	// response, to tolerate dropped requests/responses under chaos testing
+	maxSendAppRequestAttempts = 5
+)
+
+var (
+	errFailedToSendAppRequest   = errors.New("failed to send AppRequest")
+	errSignatureRequestTimedOut = errors.New("signature request timed out")
+	errUnsupported              = errors.New("unsupported")
+)
+
+// l1Chaos interleaves the validator lifecycle operations below with induced
+// faults (node restarts, dropped/duplicated p2p messages, and nonce replay)
+// to exercise convergence of the platformvm under adverse conditions, rather
+// than only the happy path.
+var l1Chaos = flag.Bool(
+	"l1-chaos",
+	false,
+	"interleave L1 validator lifecycle operations with induced faults",
 )
 
 var _ = e2e.DescribePChain("[L1]", func() {
@@ -190,19 +213,52 @@ var _ = e2e.DescribePChain("[L1]", func() {
 		genesisNodePK, err := bls.PublicKeyFromCompressedBytes(genesisNodePoP.PublicKey[:])
 		require.NoError(err)
 
-		tc.By("connecting to the genesis validator")
-		var (
-			networkID           = env.GetNetwork().GetNetworkID()
-			genesisPeerMessages = buffer.NewUnboundedBlockingDeque[p2pmessage.InboundMessage](1)
-		)
-		genesisPeer, err := peer.StartTestPeer(
-			tc.DefaultContext(),
-			subnetGenesisNode.StakingAddress,
-			networkID,
-			router.InboundHandlerFunc(func(_ context.Context, m p2pmessage.InboundMessage) {
+		networkID := env.GetNetwork().GetNetworkID()
+
+		// testSender holds one connection per canonical validator the
+		// signature aggregator may need to query; connectPeer (re)establishes
+		// the connection for a given node, keyed by its nodeID.
+		testSender := newPeerSender()
+		connectPeer := func(node *tmpnet.Node) {
+			messages := buffer.NewUnboundedBlockingDeque[p2pmessage.InboundMessage](1)
+			var handler router.InboundHandler = router.InboundHandlerFunc(func(_ context.Context, m p2pmessage.InboundMessage) {
 				tc.Outf("received %s %s from %s\n", m.Op(), m.Message(), m.NodeID())
-				genesisPeerMessages.PushRight(m)
-			}),
+				messages.PushRight(m)
+			})
+			if *l1Chaos {
+				handler = tmpnet.FlakyInboundHandler(handler, 0.1, 0.1)
+			}
+
+			p, err := peer.StartTestPeer(
+				tc.DefaultContext(),
+				node.StakingAddress,
+				networkID,
+				handler,
+			)
+			require.NoError(err)
+
+			testSender.setConnection(node.NodeID, p, messages)
+		}
+		connectGenesisPeer := func() { connectPeer(subnetGenesisNode) }
+		tc.By("connecting to the genesis validator", connectGenesisPeer)
+
+		ginkgo.DeferCleanup(func() {
+			if !ginkgo.CurrentSpecReport().Failed() {
+				return
+			}
+			tc.Outf("dumping undelivered peer messages after failure:\n")
+			testSender.dumpUndeliveredMessages(tc)
+		})
+
+		tc.By("creating the signature aggregator")
+		signatureAggregator, err := acp118.New(
+			subnetID,
+			&pChainValidatorState{
+				client:   pClient,
+				subnetID: subnetID,
+			},
+			testSender,
+			prometheus.NewRegistry(),
 		)
 		require.NoError(err)
 
@@ -318,6 +374,18 @@ var _ = e2e.DescribePChain("[L1]", func() {
 			e2e.WaitForHealthy(tc, subnetRegisterNode)
 		})
 
+		tc.By("creating the l1ValidatorOwner")
+		l1ValidatorOwner := &secp256k1fx.OutputOwners{
+			Threshold: 1,
+			Addrs: []ids.ShortID{
+				keychain.Keys[0].Address(),
+			},
+		}
+		l1ValidatorWarpOwner := warpmessage.PChainOwner{
+			Threshold: l1ValidatorOwner.Threshold,
+			Addresses: l1ValidatorOwner.Addrs,
+		}
+
 		tc.By("creating the RegisterSubnetValidatorMessage")
 		expiry := uint64(time.Now().Add(expiryDelay).Unix()) // This message will expire in 5 minutes
 		registerSubnetValidatorMessage, err := warpmessage.NewRegisterSubnetValidator(
@@ -325,8 +393,8 @@ var _ = e2e.DescribePChain("[L1]", func() {
 			subnetRegisterNode.NodeID,
 			registerNodePoP.PublicKey,
 			expiry,
-			warpmessage.PChainOwner{},
-			warpmessage.PChainOwner{},
+			l1ValidatorWarpOwner, // RemainingBalanceOwner
+			l1ValidatorWarpOwner, // DeactivationOwner
 			registerWeight,
 		)
 		require.NoError(err)
@@ -343,41 +411,60 @@ var _ = e2e.DescribePChain("[L1]", func() {
 				)).Bytes(),
 			))
 
-			tc.By("sending the request to sign the warp message", func() {
-				registerSubnetValidatorRequest, err := wrapWarpSignatureRequest(
+			var registerSubnetValidator *warp.Message
+			tc.By("aggregating a signature for the warp message", func() {
+				height, err := pClient.GetHeight(tc.DefaultContext())
+				require.NoError(err)
+
+				registerSubnetValidator, err = signatureAggregator.AggregateSignatures(
+					tc.DefaultContext(),
 					unsignedRegisterSubnetValidator,
 					nil,
+					height,
+					67,
+					100,
 				)
 				require.NoError(err)
-
-				require.True(genesisPeer.Send(tc.DefaultContext(), registerSubnetValidatorRequest))
 			})
 
-			tc.By("getting the signature response")
-			registerSubnetValidatorSignature, ok, err := findMessage(genesisPeerMessages, unwrapWarpSignature)
-			require.NoError(err)
-			require.True(ok)
-
-			tc.By("creating the signed warp message to register the validator")
-			registerSubnetValidator, err := warp.NewMessage(
-				unsignedRegisterSubnetValidator,
-				&warp.BitSetSignature{
-					Signers: set.NewBits(0).Bytes(), // [signers] has weight from the genesis peer
-					Signature: ([bls.SignatureLen]byte)(
-						bls.SignatureToBytes(registerSubnetValidatorSignature),
-					),
-				},
-			)
-			require.NoError(err)
-
-			tc.By("issuing a RegisterSubnetValidatorTx", func() {
-				_, err := pWallet.IssueRegisterSubnetValidatorTx(
-					registerBalance,
-					registerNodePoP.ProofOfPossession,
-					registerSubnetValidator.Bytes(),
-				)
-				require.NoError(err)
-			})
+			if !*l1Chaos {
+				tc.By("issuing a RegisterSubnetValidatorTx", func() {
+					_, err := pWallet.IssueRegisterSubnetValidatorTx(
+						registerBalance,
+						registerNodePoP.ProofOfPossession,
+						registerSubnetValidator.Bytes(),
+					)
+					require.NoError(err)
+				})
+			} else {
+				tc.By("killing and restarting the subnet nodes while the registration is being accepted", func() {
+					// IssueRegisterSubnetValidatorTx blocks until the tx is
+					// accepted, so it's issued asynchronously here to let the
+					// node restarts actually race its acceptance rather than
+					// following it.
+					txAccepted := make(chan error, 1)
+					go func() {
+						_, err := pWallet.IssueRegisterSubnetValidatorTx(
+							registerBalance,
+							registerNodePoP.ProofOfPossession,
+							registerSubnetValidator.Bytes(),
+						)
+						txAccepted <- err
+					}()
+
+					require.NoError(tmpnet.RestartNode(tc.DefaultContext(), subnetGenesisNode))
+					require.NoError(tmpnet.RestartNode(tc.DefaultContext(), subnetRegisterNode))
+					e2e.WaitForHealthy(tc, subnetGenesisNode)
+					e2e.WaitForHealthy(tc, subnetRegisterNode)
+
+					// The genesis validator's restart tore down the signature
+					// aggregator's connection to it; reconnect before any
+					// further signature aggregation is attempted.
+					connectGenesisPeer()
+
+					require.NoError(<-txAccepted)
+				})
+			}
 		})
 
 		tc.By("verifying the validator was registered", func() {
@@ -402,24 +489,57 @@ var _ = e2e.DescribePChain("[L1]", func() {
 				sov.StartTime = 0
 				require.Equal(
 					platformvm.SubnetOnlyValidator{
-						SubnetID:  subnetID,
-						NodeID:    subnetRegisterNode.NodeID,
-						PublicKey: registerNodePK,
-						RemainingBalanceOwner: &secp256k1fx.OutputOwners{
-							Addrs: []ids.ShortID{},
-						},
-						DeactivationOwner: &secp256k1fx.OutputOwners{
-							Addrs: []ids.ShortID{},
-						},
-						Weight:   registerWeight,
-						MinNonce: 0,
-						Balance:  0,
+						SubnetID:              subnetID,
+						NodeID:                subnetRegisterNode.NodeID,
+						PublicKey:             registerNodePK,
+						RemainingBalanceOwner: l1ValidatorOwner,
+						DeactivationOwner:     l1ValidatorOwner,
+						Weight:                registerWeight,
+						MinNonce:              0,
+						Balance:               0,
 					},
 					sov,
 				)
 			})
 		})
 
+		tc.By("increasing the balance of the validator", func() {
+			tc.By("issuing an IssueIncreaseL1ValidatorBalanceTx", func() {
+				_, err := pWallet.IssueIncreaseL1ValidatorBalanceTx(
+					registerValidationID,
+					topUpAmount,
+					tc.WithDefaultContext(),
+				)
+				require.NoError(err)
+			})
+
+			tc.By("verifying the validator became active", func() {
+				verifyValidatorSet(map[ids.NodeID]*snowvalidators.GetValidatorOutput{
+					subnetGenesisNode.NodeID: {
+						NodeID:    subnetGenesisNode.NodeID,
+						PublicKey: genesisNodePK,
+						Weight:    genesisWeight,
+					},
+					subnetRegisterNode.NodeID: {
+						NodeID:    subnetRegisterNode.NodeID,
+						PublicKey: registerNodePK,
+						Weight:    registerWeight,
+					},
+				})
+			})
+
+			tc.By("verifying the SoV reports the new balance", func() {
+				sov, _, err := pClient.GetSubnetOnlyValidator(tc.DefaultContext(), registerValidationID)
+				require.NoError(err)
+				require.Equal(topUpAmount, sov.Balance)
+			})
+		})
+
+		// The validator set now has two active members, and the signature
+		// aggregator queries both; connect to subnetRegisterNode so that its
+		// signature is collected alongside the genesis validator's.
+		tc.By("connecting to the new validator", func() { connectPeer(subnetRegisterNode) })
+
 		var nextNonce uint64
 		setWeight := func(validationID ids.ID, weight uint64) {
 			tc.By("creating the unsigned SubnetValidatorWeightMessage")
@@ -436,33 +556,22 @@ var _ = e2e.DescribePChain("[L1]", func() {
 				)).Bytes(),
 			))
 
-			tc.By("sending the request to sign the warp message", func() {
-				setSubnetValidatorWeightRequest, err := wrapWarpSignatureRequest(
+			var setSubnetValidatorWeight *warp.Message
+			tc.By("aggregating a signature for the warp message", func() {
+				height, err := pClient.GetHeight(tc.DefaultContext())
+				require.NoError(err)
+
+				setSubnetValidatorWeight, err = signatureAggregator.AggregateSignatures(
+					tc.DefaultContext(),
 					unsignedSubnetValidatorWeight,
 					nil,
+					height,
+					67,
+					100,
 				)
 				require.NoError(err)
-
-				require.True(genesisPeer.Send(tc.DefaultContext(), setSubnetValidatorWeightRequest))
 			})
 
-			tc.By("getting the signature response")
-			setSubnetValidatorWeightSignature, ok, err := findMessage(genesisPeerMessages, unwrapWarpSignature)
-			require.NoError(err)
-			require.True(ok)
-
-			tc.By("creating the signed warp message to increase the weight of the validator")
-			setSubnetValidatorWeight, err := warp.NewMessage(
-				unsignedSubnetValidatorWeight,
-				&warp.BitSetSignature{
-					Signers: set.NewBits(0).Bytes(), // [signers] has weight from the genesis peer
-					Signature: ([bls.SignatureLen]byte)(
-						bls.SignatureToBytes(setSubnetValidatorWeightSignature),
-					),
-				},
-			)
-			require.NoError(err)
-
 			tc.By("issuing a SetSubnetValidatorWeightTx", func() {
 				_, err := pWallet.IssueSetSubnetValidatorWeightTx(
 					setSubnetValidatorWeight.Bytes(),
@@ -485,9 +594,10 @@ var _ = e2e.DescribePChain("[L1]", func() {
 						PublicKey: genesisNodePK,
 						Weight:    genesisWeight,
 					},
-					ids.EmptyNodeID: { // The validator is not active
-						NodeID: ids.EmptyNodeID,
-						Weight: updatedWeight,
+					subnetRegisterNode.NodeID: {
+						NodeID:    subnetRegisterNode.NodeID,
+						PublicKey: registerNodePK,
+						Weight:    updatedWeight,
 					},
 				})
 			})
@@ -495,25 +605,111 @@ var _ = e2e.DescribePChain("[L1]", func() {
 			tc.By("verifying the SoV can be fetched", func() {
 				sov, _, err := pClient.GetSubnetOnlyValidator(tc.DefaultContext(), registerValidationID)
 				require.NoError(err)
+				require.LessOrEqual(sov.Balance, topUpAmount)
+				require.Positive(sov.Balance)
 
 				sov.StartTime = 0
+				sov.Balance = 0
 				require.Equal(
 					platformvm.SubnetOnlyValidator{
-						SubnetID:  subnetID,
+						SubnetID:              subnetID,
+						NodeID:                subnetRegisterNode.NodeID,
+						PublicKey:             registerNodePK,
+						RemainingBalanceOwner: l1ValidatorOwner,
+						DeactivationOwner:     l1ValidatorOwner,
+						Weight:                updatedWeight,
+						MinNonce:              nextNonce,
+						Balance:               0,
+					},
+					sov,
+				)
+			})
+		})
+
+		if *l1Chaos {
+			tc.By("racing the proposervm height advance against a weight update", func() {
+				go advanceProposerVMPChainHeight()
+				setWeight(registerValidationID, updatedWeight)
+
+				verifyValidatorSet(map[ids.NodeID]*snowvalidators.GetValidatorOutput{
+					subnetGenesisNode.NodeID: {
+						NodeID:    subnetGenesisNode.NodeID,
+						PublicKey: genesisNodePK,
+						Weight:    genesisWeight,
+					},
+					subnetRegisterNode.NodeID: {
 						NodeID:    subnetRegisterNode.NodeID,
 						PublicKey: registerNodePK,
-						RemainingBalanceOwner: &secp256k1fx.OutputOwners{
-							Addrs: []ids.ShortID{},
-						},
-						DeactivationOwner: &secp256k1fx.OutputOwners{
-							Addrs: []ids.ShortID{},
-						},
-						Weight:   updatedWeight,
-						MinNonce: nextNonce,
-						Balance:  0,
+						Weight:    updatedWeight,
 					},
-					sov,
+				})
+			})
+
+			tc.By("replaying an already-consumed nonce", func() {
+				replayedWeightMessage, err := warpmessage.NewSubnetValidatorWeight(
+					registerValidationID,
+					nextNonce-1,
+					updatedWeight,
+				)
+				require.NoError(err)
+
+				unsignedReplayedWeight := must[*warp.UnsignedMessage](tc)(warp.NewUnsignedMessage(
+					networkID,
+					chainID,
+					must[*payload.AddressedCall](tc)(payload.NewAddressedCall(
+						address,
+						replayedWeightMessage.Bytes(),
+					)).Bytes(),
+				))
+
+				height, err := pClient.GetHeight(tc.DefaultContext())
+				require.NoError(err)
+
+				signedReplayedWeight, err := signatureAggregator.AggregateSignatures(
+					tc.DefaultContext(),
+					unsignedReplayedWeight,
+					nil,
+					height,
+					67,
+					100,
+				)
+				require.NoError(err)
+
+				// MinNonce enforcement must reject a nonce that was already
+				// consumed by an earlier SetSubnetValidatorWeightTx.
+				_, err = pWallet.IssueSetSubnetValidatorWeightTx(signedReplayedWeight.Bytes())
+				require.Error(err)
+			})
+		}
+
+		tc.By("disabling the validator", func() {
+			tc.By("issuing an IssueDisableL1ValidatorTx signed by the DeactivationOwner", func() {
+				_, err := pWallet.IssueDisableL1ValidatorTx(
+					registerValidationID,
+					tc.WithDefaultContext(),
 				)
+				require.NoError(err)
+			})
+
+			tc.By("verifying the validator became inactive", func() {
+				verifyValidatorSet(map[ids.NodeID]*snowvalidators.GetValidatorOutput{
+					subnetGenesisNode.NodeID: {
+						NodeID:    subnetGenesisNode.NodeID,
+						PublicKey: genesisNodePK,
+						Weight:    genesisWeight,
+					},
+					ids.EmptyNodeID: { // The validator is not active
+						NodeID: ids.EmptyNodeID,
+						Weight: updatedWeight,
+					},
+				})
+			})
+
+			tc.By("verifying the SoV retains its weight but has a zero balance", func() {
+				sov, _, err := pClient.GetSubnetOnlyValidator(tc.DefaultContext(), registerValidationID)
+				require.NoError(err)
+				require.Equal(updatedWeight, sov.Weight)
+				require.Zero(sov.Balance)
 			})
 		})
 
@@ -535,18 +731,213 @@ var _ = e2e.DescribePChain("[L1]", func() {
 			})
 		})
 
-		genesisPeerMessages.Close()
-		genesisPeer.StartClose()
-		require.NoError(genesisPeer.AwaitClosed(tc.DefaultContext()))
+		tc.By("recovering from an expired registration", func() {
+			tc.By("creating the validator to register")
+			subnetExpiryNode := e2e.AddEphemeralNode(tc, env.GetNetwork(), tmpnet.FlagsMap{
+				config.TrackSubnetsKey: subnetID.String(),
+			})
+
+			expiryNodePoP, err := subnetExpiryNode.GetProofOfPossession()
+			require.NoError(err)
+
+			tc.By("ensuring the subnet node is healthy", func() {
+				e2e.WaitForHealthy(tc, subnetExpiryNode)
+			})
+
+			tc.By("creating a RegisterSubnetValidatorMessage that expires almost immediately")
+			expiringExpiry := uint64(time.Now().Add(time.Second).Unix())
+			expiringRegisterMessage, err := warpmessage.NewRegisterSubnetValidator(
+				subnetID,
+				subnetExpiryNode.NodeID,
+				expiryNodePoP.PublicKey,
+				expiringExpiry,
+				l1ValidatorWarpOwner,
+				l1ValidatorWarpOwner,
+				registerWeight,
+			)
+			require.NoError(err)
+			expiringValidationID := expiringRegisterMessage.ValidationID()
+
+			tc.By("registering the validator", func() {
+				unsignedExpiringRegister := must[*warp.UnsignedMessage](tc)(warp.NewUnsignedMessage(
+					networkID,
+					chainID,
+					must[*payload.AddressedCall](tc)(payload.NewAddressedCall(
+						address,
+						expiringRegisterMessage.Bytes(),
+					)).Bytes(),
+				))
+
+				height, err := pClient.GetHeight(tc.DefaultContext())
+				require.NoError(err)
+
+				signedExpiringRegister, err := signatureAggregator.AggregateSignatures(
+					tc.DefaultContext(),
+					unsignedExpiringRegister,
+					nil,
+					height,
+					67,
+					100,
+				)
+				require.NoError(err)
+
+				_, err = pWallet.IssueRegisterSubnetValidatorTx(
+					0,
+					expiryNodePoP.ProofOfPossession,
+					signedExpiringRegister.Bytes(),
+					tc.WithDefaultContext(),
+				)
+				require.NoError(err)
+			})
+
+			tc.By("waiting for the registration to expire", func() {
+				time.Sleep(time.Until(time.Unix(int64(expiringExpiry), 0)) + time.Second)
+			})
+
+			var removeExpired *warp.Message
+			tc.By("aggregating a signature attesting that the validator was never registered", func() {
+				nonRegistrationMessage, err := warpmessage.NewSubnetValidatorRegistration(
+					expiringValidationID,
+					false,
+				)
+				require.NoError(err)
+
+				unsignedNonRegistration := must[*warp.UnsignedMessage](tc)(warp.NewUnsignedMessage(
+					networkID,
+					chainID,
+					must[*payload.AddressedCall](tc)(payload.NewAddressedCall(
+						address,
+						nonRegistrationMessage.Bytes(),
+					)).Bytes(),
+				))
+
+				height, err := pClient.GetHeight(tc.DefaultContext())
+				require.NoError(err)
+
+				// The justification proves to the validators that the
+				// registration they are being asked to deny ever expired
+				// without being accepted.
+				removeExpired, err = signatureAggregator.AggregateSignatures(
+					tc.DefaultContext(),
+					unsignedNonRegistration,
+					expiringRegisterMessage.Bytes(),
+					height,
+					67,
+					100,
+				)
+				require.NoError(err)
+			})
+
+			tc.By("issuing the removal RegisterSubnetValidatorTx", func() {
+				_, err := pWallet.IssueRegisterSubnetValidatorTx(
+					0,
+					expiryNodePoP.ProofOfPossession,
+					removeExpired.Bytes(),
+					tc.WithDefaultContext(),
+				)
+				require.NoError(err)
+			})
+
+			tc.By("verifying the SoV was deleted", func() {
+				_, _, err := pClient.GetSubnetOnlyValidator(tc.DefaultContext(), expiringValidationID)
+				require.Error(err) // the validation ID is no longer known to the P-chain
+			})
+		})
+
+		require.NoError(testSender.closeAll(tc.DefaultContext()))
 
 		_ = e2e.CheckBootstrapIsPossible(tc, env.GetNetwork())
 	})
 })
 
-func wrapWarpSignatureRequest(
-	msg *warp.UnsignedMessage,
-	justification []byte,
-) (p2pmessage.OutboundMessage, error) {
+// peerSender adapts a directly connected test peer.Peer into an
+// acp118.Sender, so the e2e test exercises the same aggregation subsystem
+// used by non-test callers (e.g. subnet-evm-style Warp precompile
+// aggregators), rather than requesting and assembling signatures by hand.
+// Every canonical
+// validator the aggregator queries gets its own connection, registered
+// (and re-registered, after a chaos restart) by nodeID via setConnection.
+type peerSender struct {
+	mu          sync.Mutex
+	connections map[ids.NodeID]*peerConnection
+}
+
+type peerConnection struct {
+	peer     peer.Peer
+	messages buffer.BlockingDeque[p2pmessage.InboundMessage]
+
+	// nextRequestID is bumped on every AppRequest sent over this connection,
+	// so that a response can be correlated back to the attempt it answers
+	// even if a prior, timed-out attempt's finder goroutine is still
+	// scavenging the same queue.
+	nextRequestID atomic.Uint32
+}
+
+func newPeerSender() *peerSender {
+	return &peerSender{
+		connections: make(map[ids.NodeID]*peerConnection),
+	}
+}
+
+// setConnection registers (or replaces) the connection used to reach
+// [nodeID]. It must be called again with a fresh connection any time the
+// node backing an existing one restarts.
+func (s *peerSender) setConnection(nodeID ids.NodeID, p peer.Peer, messages buffer.BlockingDeque[p2pmessage.InboundMessage]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connections[nodeID] = &peerConnection{
+		peer:     p,
+		messages: messages,
+	}
+}
+
+// dumpUndeliveredMessages logs every message still queued on each
+// connection, draining them in the process.
+func (s *peerSender) dumpUndeliveredMessages(tc tests.TestContext) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for nodeID, conn := range s.connections {
+		// PopLeft blocks on an empty, open deque, so stop once it is empty.
+		for conn.messages.Len() > 0 {
+			msg, ok := conn.messages.PopLeft()
+			if !ok {
+				break
+			}
+			tc.Outf("  %s: %s %s from %s\n", nodeID, msg.Op(), msg.Message(), msg.NodeID())
+		}
+	}
+}
+
+// closeAll closes every registered connection.
+func (s *peerSender) closeAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.connections {
+		conn.messages.Close()
+		conn.peer.StartClose()
+		if err := conn.peer.AwaitClosed(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *peerSender) SendAppRequest(
+	ctx context.Context,
+	nodeID ids.NodeID,
+	chainID ids.ID,
+	requestBytes []byte,
+) ([]byte, error) {
+	s.mu.Lock()
+	conn, ok := s.connections[nodeID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: no connection registered for %s", errUnsupported, nodeID)
+	}
+
 	p2pMessageFactory, err := p2pmessage.NewCreator(
 		logging.NoLog{},
 		prometheus.NewRegistry(),
@@ -557,24 +948,101 @@ func wrapWarpSignatureRequest(
 		return nil, err
 	}
 
-	request := sdk.SignatureRequest{
-		Message:       msg.Bytes(),
-		Justification: justification,
+	appRequestBytes := p2psdk.PrefixMessage(
+		p2psdk.ProtocolPrefix(p2psdk.SignatureRequestHandlerID),
+		requestBytes,
+	)
+
+	// The underlying connection may silently drop the request or the
+	// response (e.g. under the chaos test mode's FlakyInboundHandler), so
+	// resend on each timeout rather than waiting on findMessage forever.
+	// Each attempt gets its own requestID so its response can't be
+	// mistaken for a previous, timed-out attempt's, and vice versa, even
+	// though a previous attempt's finder goroutine may still be running.
+	for attempt := 0; attempt < maxSendAppRequestAttempts; attempt++ {
+		requestID := conn.nextRequestID.Add(1)
+
+		request, err := p2pMessageFactory.AppRequest(chainID, requestID, time.Hour, appRequestBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if !conn.peer.Send(ctx, request) {
+			return nil, errFailedToSendAppRequest
+		}
+
+		responseBytes, ok, err := findMessageWithTimeout(ctx, conn.messages, unwrapAppResponse(requestID), p2pTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return responseBytes, nil
+		}
 	}
-	requestBytes, err := proto.Marshal(&request)
-	if err != nil {
-		return nil, err
+	return nil, errSignatureRequestTimedOut
+}
+
+// pChainValidatorState adapts a platformvm.Client into a validators.State,
+// so the e2e test can discover its L1's validator set the same way a
+// production aggregator caller would.
+type pChainValidatorState struct {
+	client   platformvm.Client
+	subnetID ids.ID
+}
+
+func (s *pChainValidatorState) GetMinimumHeight(ctx context.Context) (uint64, error) {
+	return s.client.GetHeight(ctx)
+}
+
+func (s *pChainValidatorState) GetCurrentHeight(ctx context.Context) (uint64, error) {
+	return s.client.GetHeight(ctx)
+}
+
+func (*pChainValidatorState) GetSubnetID(_ context.Context, _ ids.ID) (ids.ID, error) {
+	return ids.Empty, errUnsupported
+}
+
+func (s *pChainValidatorState) GetValidatorSet(
+	ctx context.Context,
+	height uint64,
+	_ ids.ID,
+) (map[ids.NodeID]*snowvalidators.GetValidatorOutput, error) {
+	return s.client.GetValidatorsAt(ctx, s.subnetID, height)
+}
+
+// findMessageWithTimeout behaves like findMessage, but gives up and returns
+// (zero, false, nil) if no matching message arrives within [timeout] or
+// [ctx] is done first, instead of blocking on q.PopLeft forever. The
+// abandoned findMessage call keeps running in the background so it can
+// still requeue any non-matching messages it pops in the meantime; callers
+// whose [parser] only matches a single correlation ID (e.g. a requestID)
+// are therefore safe to call concurrently/repeatedly against the same [q]
+// without an abandoned call's eventual result clobbering a later one's.
+func findMessageWithTimeout[T any](
+	ctx context.Context,
+	q buffer.BlockingDeque[p2pmessage.InboundMessage],
+	parser func(p2pmessage.InboundMessage) (T, bool, error),
+	timeout time.Duration,
+) (T, bool, error) {
+	type result struct {
+		value T
+		ok    bool
+		err   error
 	}
+	resultChan := make(chan result, 1)
+	go func() {
+		value, ok, err := findMessage(q, parser)
+		resultChan <- result{value: value, ok: ok, err: err}
+	}()
 
-	return p2pMessageFactory.AppRequest(
-		msg.SourceChainID,
-		0,
-		time.Hour,
-		p2psdk.PrefixMessage(
-			p2psdk.ProtocolPrefix(p2psdk.SignatureRequestHandlerID),
-			requestBytes,
-		),
-	)
+	select {
+	case res := <-resultChan:
+		return res.value, res.ok, res.err
+	case <-time.After(timeout):
+		return utils.Zero[T](), false, nil
+	case <-ctx.Done():
+		return utils.Zero[T](), false, ctx.Err()
+	}
 }
 
 func findMessage[T any](
@@ -607,26 +1075,29 @@ func findMessage[T any](
 	}
 }
 
-// unwrapWarpSignature assumes the only type of AppResponses that will be
-// received are ACP-118 compliant responses.
-func unwrapWarpSignature(msg p2pmessage.InboundMessage) (*bls.Signature, bool, error) {
-	var appResponse *p2ppb.AppResponse
-	switch msg := msg.Message().(type) {
-	case *p2ppb.AppResponse:
-		appResponse = msg
-	case *p2ppb.AppError:
-		return nil, false, errors.New(msg.ErrorMessage)
-	default:
-		return nil, false, nil
-	}
-
-	var response sdk.SignatureResponse
-	if err := proto.Unmarshal(appResponse.AppBytes, &response); err != nil {
-		return nil, false, err
+// unwrapAppResponse returns a parser that matches only the AppResponse (or
+// AppError) answering [requestID], assumed to be ACP-118 compliant, and
+// returns its raw AppBytes for the aggregator to unmarshal. Messages
+// answering a different requestID (e.g. a late response to a prior,
+// already-timed-out attempt) are left for their own attempt's parser to
+// find instead of being mistaken for this one's.
+func unwrapAppResponse(requestID uint32) func(p2pmessage.InboundMessage) ([]byte, bool, error) {
+	return func(msg p2pmessage.InboundMessage) ([]byte, bool, error) {
+		switch msg := msg.Message().(type) {
+		case *p2ppb.AppResponse:
+			if msg.RequestId != requestID {
+				return nil, false, nil
+			}
+			return msg.AppBytes, true, nil
+		case *p2ppb.AppError:
+			if msg.RequestId != requestID {
+				return nil, false, nil
+			}
+			return nil, false, errors.New(msg.ErrorMessage)
+		default:
+			return nil, false, nil
+		}
 	}
-
-	warpSignature, err := bls.SignatureFromBytes(response.Signature)
-	return warpSignature, true, err
 }
 
 func must[T any](t require.TestingT) func(T, error) T {
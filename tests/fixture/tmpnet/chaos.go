@@ -0,0 +1,44 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tmpnet
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/ava-labs/avalanchego/message"
+	"github.com/ava-labs/avalanchego/snow/networking/router"
+)
+
+// RestartNode stops [node] and starts it again with its existing
+// configuration, simulating a crash/restart fault occurring between two
+// operations a test performs against it.
+func RestartNode(ctx context.Context, node *Node) error {
+	if err := node.Stop(ctx); err != nil {
+		return err
+	}
+	return node.Start(ctx)
+}
+
+// FlakyInboundHandler wraps [handler] so that inbound messages are dropped
+// with probability [dropProbability] and duplicated with probability
+// [duplicateProbability], simulating a lossy, duplicating p2p transport.
+// It is intended for chaos/fault-injection test modes; callers should not
+// rely on the exact distribution of faults it induces.
+func FlakyInboundHandler(
+	handler router.InboundHandler,
+	dropProbability float64,
+	duplicateProbability float64,
+) router.InboundHandlerFunc {
+	return func(ctx context.Context, msg message.InboundMessage) {
+		if rand.Float64() < dropProbability { //nolint:gosec // fault injection has no need for a CSPRNG
+			return
+		}
+
+		handler.HandleInbound(ctx, msg)
+		if rand.Float64() < duplicateProbability { //nolint:gosec // fault injection has no need for a CSPRNG
+			handler.HandleInbound(ctx, msg)
+		}
+	}
+}
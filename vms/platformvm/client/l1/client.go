@@ -0,0 +1,340 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package l1 provides a high-level client for managing the lifecycle of L1
+// validators, so that callers don't need to reimplement warp message
+// construction, signature aggregation, and transaction issuance themselves.
+package l1
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/network/p2p/acp118"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+	"github.com/ava-labs/avalanchego/vms/platformvm/signer"
+	"github.com/ava-labs/avalanchego/vms/platformvm/txs"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ava-labs/avalanchego/vms/platformvm/warp/payload"
+	warpmessage "github.com/ava-labs/avalanchego/vms/platformvm/warp/message"
+	"github.com/ava-labs/avalanchego/wallet/chain/p"
+	"github.com/ava-labs/avalanchego/wallet/subnet/primary/common"
+)
+
+const (
+	defaultQuorumNumerator   = 67
+	defaultQuorumDenominator = 100
+
+	pollInterval = time.Second
+)
+
+// Client manages the full lifecycle of L1 validators: converting a Subnet
+// into an L1, registering and removing validators, and adjusting their
+// weight and balance. Each method builds the required warp message,
+// aggregates an ACP-118 signature for it when one is required, issues the
+// resulting transaction through [wallet], and, except for ConvertSubnet,
+// polls the validation ID's status until it reflects the requested change.
+type Client struct {
+	wallet     p.Wallet
+	pClient    platformvm.Client
+	aggregator *acp118.Aggregator
+	networkID  uint32
+	chainID    ids.ID
+	addr       []byte
+}
+
+// New returns a Client that manages the L1 whose Subnet-Manager is
+// identified by ([chainID], [addr]), as set by ConvertSubnetTx.
+// Transactions are issued through [wallet]; ACP-118 signatures are collected
+// through [aggregator].
+func New(
+	wallet p.Wallet,
+	pClient platformvm.Client,
+	aggregator *acp118.Aggregator,
+	networkID uint32,
+	chainID ids.ID,
+	addr []byte,
+) *Client {
+	return &Client{
+		wallet:     wallet,
+		pClient:    pClient,
+		aggregator: aggregator,
+		networkID:  networkID,
+		chainID:    chainID,
+		addr:       addr,
+	}
+}
+
+// ConvertSubnet converts the Permissioned Subnet identified by [subnetID]
+// into an L1 with the given initial [validators].
+func (c *Client) ConvertSubnet(
+	ctx context.Context,
+	subnetID ids.ID,
+	validators []*txs.ConvertSubnetValidator,
+	options ...common.Option,
+) (*txs.Tx, error) {
+	return c.wallet.IssueConvertSubnetTx(subnetID, c.chainID, c.addr, validators, c.withContext(ctx, options)...)
+}
+
+// RegisterValidator registers a new L1 validator identified by [nodeID] and
+// the BLS key proven by [pop], with the given [weight] and initial
+// [balance]. Ownership of the validator's remaining balance and
+// deactivation rights are assigned to [remainingBalanceOwner] and
+// [deactivationOwner] respectively. It returns once the validation ID is
+// recognized by the P-chain; a [balance] of 0 registers the validator
+// inactive, so callers relying on it becoming active must follow up with
+// IncreaseBalance.
+func (c *Client) RegisterValidator(
+	ctx context.Context,
+	subnetID ids.ID,
+	nodeID ids.NodeID,
+	pop signer.ProofOfPossession,
+	expiry uint64,
+	remainingBalanceOwner warpmessage.PChainOwner,
+	deactivationOwner warpmessage.PChainOwner,
+	weight uint64,
+	balance uint64,
+	options ...common.Option,
+) (ids.ID, error) {
+	registerMessage, err := warpmessage.NewRegisterSubnetValidator(
+		subnetID,
+		nodeID,
+		pop.PublicKey,
+		expiry,
+		remainingBalanceOwner,
+		deactivationOwner,
+		weight,
+	)
+	if err != nil {
+		return ids.Empty, fmt.Errorf("failed to build RegisterSubnetValidatorMessage: %w", err)
+	}
+	validationID := registerMessage.ValidationID()
+
+	signedMessage, err := c.aggregateSignature(ctx, registerMessage.Bytes(), nil)
+	if err != nil {
+		return ids.Empty, err
+	}
+
+	if _, err := c.wallet.IssueRegisterSubnetValidatorTx(
+		balance,
+		pop.ProofOfPossession,
+		signedMessage.Bytes(),
+		c.withContext(ctx, options)...,
+	); err != nil {
+		return ids.Empty, fmt.Errorf("failed to issue RegisterSubnetValidatorTx: %w", err)
+	}
+
+	if balance == 0 {
+		// A zero-balance registration never activates on its own; just
+		// confirm the SoV was created rather than waiting for it to go
+		// active.
+		return validationID, c.awaitExists(ctx, validationID)
+	}
+	return validationID, c.awaitActive(ctx, validationID)
+}
+
+// SetValidatorWeight updates the weight of the L1 validator identified by
+// [validationID] to [weight]. A [weight] of 0 removes the validator. [nonce]
+// must be greater than the nonce of every previously accepted weight update
+// for [validationID].
+func (c *Client) SetValidatorWeight(
+	ctx context.Context,
+	validationID ids.ID,
+	nonce uint64,
+	weight uint64,
+	options ...common.Option,
+) error {
+	weightMessage, err := warpmessage.NewSubnetValidatorWeight(validationID, nonce, weight)
+	if err != nil {
+		return fmt.Errorf("failed to build SubnetValidatorWeightMessage: %w", err)
+	}
+
+	signedMessage, err := c.aggregateSignature(ctx, weightMessage.Bytes(), nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.wallet.IssueSetSubnetValidatorWeightTx(signedMessage.Bytes(), c.withContext(ctx, options)...); err != nil {
+		return fmt.Errorf("failed to issue SetSubnetValidatorWeightTx: %w", err)
+	}
+
+	if weight == 0 {
+		// A weight of 0 removes the validator, so its SoV stops existing
+		// rather than ever reporting a weight of 0.
+		return c.awaitRemoved(ctx, validationID)
+	}
+	return c.awaitWeight(ctx, validationID, weight)
+}
+
+// IncreaseBalance adds [amount] to the continuous-fee balance backing the L1
+// validator identified by [validationID].
+func (c *Client) IncreaseBalance(
+	ctx context.Context,
+	validationID ids.ID,
+	amount uint64,
+	options ...common.Option,
+) error {
+	if _, err := c.wallet.IssueIncreaseL1ValidatorBalanceTx(validationID, amount, c.withContext(ctx, options)...); err != nil {
+		return fmt.Errorf("failed to issue IncreaseL1ValidatorBalanceTx: %w", err)
+	}
+	return c.awaitBalanceAtLeast(ctx, validationID, amount)
+}
+
+// Disable deactivates the L1 validator identified by [validationID]. The
+// transaction must be authorized by the validator's DeactivationOwner, as
+// supplied via [options].
+func (c *Client) Disable(
+	ctx context.Context,
+	validationID ids.ID,
+	options ...common.Option,
+) error {
+	if _, err := c.wallet.IssueDisableL1ValidatorTx(validationID, c.withContext(ctx, options)...); err != nil {
+		return fmt.Errorf("failed to issue DisableL1ValidatorTx: %w", err)
+	}
+	return c.awaitInactive(ctx, validationID)
+}
+
+// RemoveExpired removes the never-completed registration of the L1 validator
+// described by [registerMessage], whose expiry has already elapsed. It
+// aggregates a signature attesting that the validator was never registered
+// and submits it as justification for the removal.
+func (c *Client) RemoveExpired(
+	ctx context.Context,
+	registerMessage *warpmessage.RegisterSubnetValidator,
+	options ...common.Option,
+) error {
+	validationID := registerMessage.ValidationID()
+
+	nonRegistrationMessage, err := warpmessage.NewSubnetValidatorRegistration(validationID, false)
+	if err != nil {
+		return fmt.Errorf("failed to build SubnetValidatorRegistrationMessage: %w", err)
+	}
+
+	signedMessage, err := c.aggregateSignature(ctx, nonRegistrationMessage.Bytes(), registerMessage.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.wallet.IssueRegisterSubnetValidatorTx(
+		0,
+		signer.ProofOfPossession{},
+		signedMessage.Bytes(),
+		c.withContext(ctx, options)...,
+	); err != nil {
+		return fmt.Errorf("failed to issue removal RegisterSubnetValidatorTx: %w", err)
+	}
+
+	return c.awaitRemoved(ctx, validationID)
+}
+
+// withContext prepends common.WithContext(ctx) to [options], so that
+// cancelling [ctx] also cancels the wallet's underlying tx issuance call,
+// not just this Client's subsequent polling.
+func (c *Client) withContext(ctx context.Context, options []common.Option) []common.Option {
+	return append([]common.Option{common.WithContext(ctx)}, options...)
+}
+
+func (c *Client) aggregateSignature(ctx context.Context, payloadBytes []byte, justification []byte) (*warp.Message, error) {
+	addressedCall, err := payload.NewAddressedCall(c.addr, payloadBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build AddressedCall: %w", err)
+	}
+
+	unsignedMessage, err := warp.NewUnsignedMessage(c.networkID, c.chainID, addressedCall.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build UnsignedMessage: %w", err)
+	}
+
+	height, err := c.pClient.GetHeight(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch P-chain height: %w", err)
+	}
+
+	return c.aggregator.AggregateSignatures(
+		ctx,
+		unsignedMessage,
+		justification,
+		height,
+		defaultQuorumNumerator,
+		defaultQuorumDenominator,
+	)
+}
+
+func (c *Client) awaitActive(ctx context.Context, validationID ids.ID) error {
+	return c.poll(ctx, func() (bool, error) {
+		_, isActive, err := c.pClient.GetSubnetOnlyValidator(ctx, validationID)
+		return isActive, err
+	})
+}
+
+// awaitExists waits for [validationID] to be recognized as an SoV, without
+// requiring it to be active.
+func (c *Client) awaitExists(ctx context.Context, validationID ids.ID) error {
+	return c.poll(ctx, func() (bool, error) {
+		_, _, err := c.pClient.GetSubnetOnlyValidator(ctx, validationID)
+		return err == nil, nil
+	})
+}
+
+func (c *Client) awaitInactive(ctx context.Context, validationID ids.ID) error {
+	return c.poll(ctx, func() (bool, error) {
+		_, isActive, err := c.pClient.GetSubnetOnlyValidator(ctx, validationID)
+		return !isActive, err
+	})
+}
+
+func (c *Client) awaitWeight(ctx context.Context, validationID ids.ID, weight uint64) error {
+	return c.poll(ctx, func() (bool, error) {
+		sov, _, err := c.pClient.GetSubnetOnlyValidator(ctx, validationID)
+		if err != nil {
+			return false, err
+		}
+		return sov.Weight == weight, nil
+	})
+}
+
+func (c *Client) awaitBalanceAtLeast(ctx context.Context, validationID ids.ID, minBalance uint64) error {
+	return c.poll(ctx, func() (bool, error) {
+		sov, _, err := c.pClient.GetSubnetOnlyValidator(ctx, validationID)
+		if err != nil {
+			return false, err
+		}
+		return sov.Balance >= minBalance, nil
+	})
+}
+
+// awaitRemoved waits for [validationID] to stop being a recognized SoV. Any
+// error returned by the lookup is treated as evidence of removal, since the
+// P-chain surfaces an unknown validation ID as a client error rather than a
+// typed not-found response.
+func (c *Client) awaitRemoved(ctx context.Context, validationID ids.ID) error {
+	return c.poll(ctx, func() (bool, error) {
+		_, _, err := c.pClient.GetSubnetOnlyValidator(ctx, validationID)
+		return err != nil, nil
+	})
+}
+
+// poll repeatedly invokes [check] until it returns true, returns an error,
+// or [ctx] is done.
+func (c *Client) poll(ctx context.Context, check func() (bool, error)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
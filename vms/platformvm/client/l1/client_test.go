@@ -0,0 +1,83 @@
+// Copyright (C) 2019-2024, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package l1
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/rpc"
+	"github.com/ava-labs/avalanchego/vms/platformvm"
+)
+
+var errValidationIDNotFound = errors.New("validation ID not found")
+
+// fakePClient embeds platformvm.Client so tests only need to override
+// GetSubnetOnlyValidator, the only method the await* helpers call.
+type fakePClient struct {
+	platformvm.Client
+
+	sov      platformvm.SubnetOnlyValidator
+	isActive bool
+	err      error
+}
+
+func (f *fakePClient) GetSubnetOnlyValidator(context.Context, ids.ID, ...rpc.Option) (platformvm.SubnetOnlyValidator, bool, error) {
+	return f.sov, f.isActive, f.err
+}
+
+func TestAwaitWeight(t *testing.T) {
+	require := require.New(t)
+
+	c := &Client{pClient: &fakePClient{sov: platformvm.SubnetOnlyValidator{Weight: 100}}}
+	require.NoError(c.awaitWeight(context.Background(), ids.GenerateTestID(), 100))
+}
+
+func TestAwaitWeight_PropagatesLookupError(t *testing.T) {
+	require := require.New(t)
+
+	// Once a validator is actually removed, the P-chain surfaces an unknown
+	// validation ID as an error rather than a weight of 0 -- awaitWeight must
+	// not be used to confirm removal. This is why SetValidatorWeight
+	// special-cases weight == 0 to call awaitRemoved instead.
+	c := &Client{pClient: &fakePClient{err: errValidationIDNotFound}}
+	err := c.awaitWeight(context.Background(), ids.GenerateTestID(), 0)
+	require.ErrorIs(err, errValidationIDNotFound)
+}
+
+func TestAwaitRemoved_TreatsLookupErrorAsRemoval(t *testing.T) {
+	require := require.New(t)
+
+	c := &Client{pClient: &fakePClient{err: errValidationIDNotFound}}
+	require.NoError(c.awaitRemoved(context.Background(), ids.GenerateTestID()))
+}
+
+func TestAwaitExists(t *testing.T) {
+	require := require.New(t)
+
+	c := &Client{pClient: &fakePClient{}}
+	require.NoError(c.awaitExists(context.Background(), ids.GenerateTestID()))
+}
+
+func TestAwaitBalanceAtLeast(t *testing.T) {
+	require := require.New(t)
+
+	c := &Client{pClient: &fakePClient{sov: platformvm.SubnetOnlyValidator{Balance: 10}}}
+	require.NoError(c.awaitBalanceAtLeast(context.Background(), ids.GenerateTestID(), 5))
+}
+
+func TestPoll_ReturnsContextErrorWhenCancelled(t *testing.T) {
+	require := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := &Client{}
+	err := c.poll(ctx, func() (bool, error) { return false, nil })
+	require.ErrorIs(err, context.Canceled)
+}